@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/pkg/apis/mongodb/v1"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/config"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/controller/mongodb"
+
+	"go.uber.org/zap"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func main() {
+	configPath := config.AddFlags(flag.CommandLine)
+	flag.Parse()
+
+	log := zap.S()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatalf("error loading operator config: %s", err)
+	}
+
+	opts := cfg.ToManagerOptions()
+	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), opts)
+	if err != nil {
+		log.Fatalf("error creating manager: %s", err)
+	}
+
+	if err := mdbv1.AddToScheme(mgr.GetScheme()); err != nil {
+		log.Fatalf("error registering MongoDB types with the manager's scheme: %s", err)
+	}
+
+	if err := mongodb.Add(mgr, cfg); err != nil {
+		log.Fatalf("error adding the MongoDB controller to the manager: %s", err)
+	}
+
+	log.Info("Starting manager")
+	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+		log.Errorf("manager exited non-zero: %s", err)
+		os.Exit(1)
+	}
+}
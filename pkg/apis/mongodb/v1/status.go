@@ -0,0 +1,73 @@
+package v1
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Phase represents the overall lifecycle phase of a MongoDB resource.
+type Phase string
+
+const (
+	PhasePending Phase = "Pending"
+	PhaseRunning Phase = "Running"
+	PhaseFailed  Phase = "Failed"
+)
+
+// Condition types reported on MongoDBStatus.Conditions.
+const (
+	ConditionTypeStatefulSetReady        = "StatefulSetReady"
+	ConditionTypeAutomationConfigApplied = "AutomationConfigApplied"
+	ConditionTypeRollingUpdateInProgress = "RollingUpdateInProgress"
+)
+
+// MongoDBStatus is the observed state of a MongoDB resource.
+type MongoDBStatus struct {
+	Phase                          Phase              `json:"phase,omitempty"`
+	CurrentStatefulSetReplicas     int                `json:"currentStatefulSetReplicas,omitempty"`
+	CurrentMongoDBMembers          int                `json:"currentMongoDBMembers,omitempty"`
+	CurrentAutomationConfigVersion int                `json:"currentAutomationConfigVersion,omitempty"`
+	ObservedGeneration             int64              `json:"observedGeneration,omitempty"`
+	Conditions                     []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// Updater patches only the status subresource of a MongoDB resource, so that
+// concurrent spec edits made by users are never clobbered by a status write.
+type Updater struct {
+	client client.Client
+}
+
+// NewStatusUpdater returns an Updater that patches through the given client.
+func NewStatusUpdater(c client.Client) *Updater {
+	return &Updater{client: c}
+}
+
+// Update applies modify to mdb.Status in place and patches just the status
+// subresource with the difference from a copy of mdb taken beforehand, leaving
+// the spec as last observed by the caller untouched.
+func (u *Updater) Update(ctx context.Context, mdb *MongoDB, modify func(*MongoDBStatus)) error {
+	patch := client.MergeFrom(mdb.DeepCopy())
+	modify(&mdb.Status)
+	return u.client.Status().Patch(ctx, mdb, patch)
+}
+
+// SetCondition upserts a condition by type, overwriting any existing entry with the same Type.
+// LastTransitionTime is only bumped when the condition is new or its Status actually changed, so
+// it reflects when the condition last flipped rather than when it was last merely re-reported.
+func (s *MongoDBStatus) SetCondition(c metav1.Condition) {
+	for i := range s.Conditions {
+		if s.Conditions[i].Type == c.Type {
+			if s.Conditions[i].Status == c.Status {
+				c.LastTransitionTime = s.Conditions[i].LastTransitionTime
+			} else {
+				c.LastTransitionTime = metav1.Now()
+			}
+			s.Conditions[i] = c
+			return
+		}
+	}
+	c.LastTransitionTime = metav1.Now()
+	s.Conditions = append(s.Conditions, c)
+}
@@ -0,0 +1,93 @@
+package automationconfig
+
+// ReplicaSetTopology is the only topology currently supported by the operator.
+const ReplicaSetTopology = "ReplicaSet"
+
+// AutomationConfig is the document the automation agent reads to know what replica set to form.
+type AutomationConfig struct {
+	Version        int       `json:"version"`
+	Topology       string    `json:"topology"`
+	Name           string    `json:"name"`
+	Domain         string    `json:"domain"`
+	Members        int       `json:"members"`
+	MongoDBVersion string    `json:"mongoDBVersion"`
+	Processes      []Process `json:"processes"`
+	TLS            TLS       `json:"tls"`
+	Auth           Auth      `json:"auth"`
+}
+
+// Process describes a single mongod process the agent is responsible for running.
+type Process struct {
+	Name     string `json:"name"`
+	HostName string `json:"hostName"`
+}
+
+// TLS holds the replica set's transport-encryption settings.
+type TLS struct {
+	Enabled bool `json:"enabled"`
+}
+
+// Auth holds the replica set's authentication settings.
+type Auth struct {
+	Enabled bool `json:"enabled"`
+}
+
+// Builder builds an AutomationConfig through a fluent API.
+type Builder struct {
+	ac AutomationConfig
+}
+
+// NewBuilder returns an empty Builder.
+func NewBuilder() *Builder {
+	return &Builder{}
+}
+
+func (b *Builder) SetTopology(topology string) *Builder {
+	b.ac.Topology = topology
+	return b
+}
+
+func (b *Builder) SetName(name string) *Builder {
+	b.ac.Name = name
+	return b
+}
+
+func (b *Builder) SetDomain(domain string) *Builder {
+	b.ac.Domain = domain
+	return b
+}
+
+func (b *Builder) SetMembers(members int) *Builder {
+	b.ac.Members = members
+	return b
+}
+
+func (b *Builder) SetMongoDBVersion(version string) *Builder {
+	b.ac.MongoDBVersion = version
+	return b
+}
+
+func (b *Builder) SetAutomationConfigVersion(version int) *Builder {
+	b.ac.Version = version
+	return b
+}
+
+func (b *Builder) SetProcesses(processes []Process) *Builder {
+	b.ac.Processes = processes
+	return b
+}
+
+func (b *Builder) SetTLS(tls TLS) *Builder {
+	b.ac.TLS = tls
+	return b
+}
+
+func (b *Builder) SetAuth(auth Auth) *Builder {
+	b.ac.Auth = auth
+	return b
+}
+
+// Build returns the assembled AutomationConfig.
+func (b *Builder) Build() AutomationConfig {
+	return b.ac
+}
@@ -0,0 +1,25 @@
+package automationconfig
+
+import "reflect"
+
+// Diff reports whether the logical contents of an AutomationConfig have changed between
+// prev and next, ignoring Version -- Version is what callers bump *because* of the result
+// of this comparison, so it must never be part of it. The returned fields name every
+// top-level field that differs, for logging.
+func Diff(prev, next AutomationConfig) (changed bool, fields []string) {
+	prevVal := reflect.ValueOf(prev)
+	nextVal := reflect.ValueOf(next)
+	t := prevVal.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		name := t.Field(i).Name
+		if name == "Version" {
+			continue
+		}
+		if !reflect.DeepEqual(prevVal.Field(i).Interface(), nextVal.Field(i).Interface()) {
+			fields = append(fields, name)
+		}
+	}
+
+	return len(fields) > 0, fields
+}
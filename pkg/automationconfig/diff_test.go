@@ -0,0 +1,74 @@
+package automationconfig
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	base := AutomationConfig{
+		Version:        3,
+		Topology:       ReplicaSetTopology,
+		Name:           "rs0",
+		Domain:         "rs0.my-namespace.svc.cluster.local",
+		Members:        3,
+		MongoDBVersion: "4.2.0",
+	}
+
+	tests := []struct {
+		name        string
+		next        AutomationConfig
+		wantChanged bool
+		wantFields  []string
+	}{
+		{
+			name:        "identical configs report no change",
+			next:        base,
+			wantChanged: false,
+		},
+		{
+			name:        "version-only change is not a logical change",
+			next:        withVersion(base, base.Version+1),
+			wantChanged: false,
+		},
+		{
+			name:        "members changed",
+			next:        withMembers(base, base.Members+2),
+			wantChanged: true,
+			wantFields:  []string{"Members"},
+		},
+		{
+			name:        "mongodb version changed",
+			next:        withMongoDBVersion(base, "4.4.0"),
+			wantChanged: true,
+			wantFields:  []string{"MongoDBVersion"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			changed, fields := Diff(base, tc.next)
+			if changed != tc.wantChanged {
+				t.Errorf("Diff() changed = %v, want %v (fields=%v)", changed, tc.wantChanged, fields)
+			}
+			if !reflect.DeepEqual(fields, tc.wantFields) {
+				t.Errorf("Diff() fields = %v, want %v", fields, tc.wantFields)
+			}
+		})
+	}
+}
+
+func withVersion(ac AutomationConfig, v int) AutomationConfig {
+	ac.Version = v
+	return ac
+}
+
+func withMembers(ac AutomationConfig, members int) AutomationConfig {
+	ac.Members = members
+	return ac
+}
+
+func withMongoDBVersion(ac AutomationConfig, version string) AutomationConfig {
+	ac.MongoDBVersion = version
+	return ac
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/yaml"
+)
+
+// leaderElectionID identifies the lock used to elect a single active operator replica when
+// LeaderElection is enabled.
+const leaderElectionID = "mongodb-kubernetes-operator-lock"
+
+// Environment variable fallbacks, read when the corresponding field isn't set in the config file.
+// These replace the ad-hoc os.Getenv calls that used to be scattered across the controller.
+const (
+	agentImageEnvVariable              = "AGENT_IMAGE"
+	mongoDBImageRepoEnvVariable        = "MONGODB_IMAGE_REPOSITORY"
+	versionUpgradeHookImageEnvVariable = "VERSION_UPGRADE_HOOK_IMAGE"
+	watchNamespaceEnvVariable          = "WATCH_NAMESPACE"
+)
+
+// OperatorConfig holds the operator-wide settings that used to be read piecemeal from individual
+// environment variables at the point of use. It is resolved once at startup and threaded through
+// to anything that needs an image name, a default resource, or namespace scoping.
+type OperatorConfig struct {
+	AgentImage              string          `json:"agentImage,omitempty"`
+	MongoDBImageRepo        string          `json:"mongoDBImageRepo,omitempty"`
+	VersionUpgradeHookImage string          `json:"versionUpgradeHookImage,omitempty"`
+	WatchNamespace          string          `json:"watchNamespace,omitempty"`
+	ReconcilePeriod         metav1.Duration `json:"reconcilePeriod,omitempty"`
+	LeaderElection          bool            `json:"leaderElection,omitempty"`
+}
+
+// AddFlags registers the --config flag on fs and returns the path it will be parsed into.
+func AddFlags(fs *flag.FlagSet) *string {
+	return fs.String("config", "", "Path to a YAML file containing the OperatorConfig. "+
+		"Unset fields fall back to environment variables, then to built-in defaults.")
+}
+
+// Load resolves an OperatorConfig from the YAML file at path, if any, then fills in anything
+// still unset from the environment. path may be empty, in which case the config is built
+// entirely from the environment and defaults.
+func Load(path string) (OperatorConfig, error) {
+	cfg := OperatorConfig{
+		ReconcilePeriod: metav1.Duration{},
+	}
+
+	if path != "" {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return OperatorConfig{}, err
+		}
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			return OperatorConfig{}, err
+		}
+	}
+
+	applyEnvOverrides(&cfg)
+	return cfg, nil
+}
+
+// ToManagerOptions translates an OperatorConfig into the manager.Options that cmd/manager passes
+// to manager.New, so WatchNamespace, ReconcilePeriod and LeaderElection are actually applied
+// instead of sitting unused on the resolved config.
+func (cfg OperatorConfig) ToManagerOptions() manager.Options {
+	opts := manager.Options{
+		Namespace:        cfg.WatchNamespace,
+		LeaderElection:   cfg.LeaderElection,
+		LeaderElectionID: leaderElectionID,
+	}
+	if cfg.ReconcilePeriod.Duration > 0 {
+		opts.SyncPeriod = &cfg.ReconcilePeriod.Duration
+	}
+	return opts
+}
+
+func applyEnvOverrides(cfg *OperatorConfig) {
+	if cfg.AgentImage == "" {
+		cfg.AgentImage = os.Getenv(agentImageEnvVariable)
+	}
+	if cfg.MongoDBImageRepo == "" {
+		cfg.MongoDBImageRepo = os.Getenv(mongoDBImageRepoEnvVariable)
+	}
+	if cfg.VersionUpgradeHookImage == "" {
+		cfg.VersionUpgradeHookImage = os.Getenv(versionUpgradeHookImageEnvVariable)
+	}
+	if cfg.WatchNamespace == "" {
+		cfg.WatchNamespace = os.Getenv(watchNamespaceEnvVariable)
+	}
+}
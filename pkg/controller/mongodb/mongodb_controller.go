@@ -4,13 +4,15 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os"
+	"time"
 
 	mdbv1 "github.com/mongodb/mongodb-kubernetes-operator/pkg/apis/mongodb/v1"
 	"github.com/mongodb/mongodb-kubernetes-operator/pkg/automationconfig"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/config"
 	mdbClient "github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/client"
 	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/configmap"
 	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/resourcerequirements"
+	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/service"
 	"github.com/mongodb/mongodb-kubernetes-operator/pkg/kube/statefulset"
 	"go.uber.org/zap"
 	appsv1 "k8s.io/api/apps/v1"
@@ -18,45 +20,100 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
-	"sigs.k8s.io/controller-runtime/pkg/controller"
-	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
-	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
+// statusRequeueAfter is how long to wait before re-checking a StatefulSet
+// that hasn't finished rolling out all of its replicas yet.
+const statusRequeueAfter = 10 * time.Second
+
 const (
-	automationConfigKey   = "automation-config"
-	agentName             = "mongodb-agent"
-	agentImageEnvVariable = "AGENT_IMAGE"
+	automationConfigKey = "automation-config"
+	agentName           = "mongodb-agent"
 )
 
+// podLabels returns the labels applied to mdb's StatefulSet pod template and selector, and to its
+// headless Service's selector, so the Service routes to exactly the pods of that StatefulSet and
+// not to those of any other MongoDB resource reconciled in the same namespace.
+func podLabels(mdb mdbv1.MongoDB) map[string]string {
+	return map[string]string{
+		"app": mdb.Name,
+	}
+}
+
 // Add creates a new MongoDB Controller and adds it to the Manager. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
-func Add(mgr manager.Manager) error {
-	return add(mgr, newReconciler(mgr))
+func Add(mgr manager.Manager, cfg config.OperatorConfig) error {
+	return add(mgr, newReconciler(mgr, cfg))
 }
 
 // newReconciler returns a new reconcile.Reconciler
-func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+func newReconciler(mgr manager.Manager, cfg config.OperatorConfig) reconcile.Reconciler {
 	mgrClient := mgr.GetClient()
-	return &ReplicaSetReconciler{client: mdbClient.NewClient(mgrClient), scheme: mgr.GetScheme()}
+	return &ReplicaSetReconciler{
+		client:        mdbClient.NewClient(mgrClient),
+		scheme:        mgr.GetScheme(),
+		statusUpdater: mdbv1.NewStatusUpdater(mgrClient),
+		cfg:           cfg,
+	}
 }
 
-// add adds a new Controller to mgr with r as the reconcile.Reconciler
+// add adds a new Controller to mgr with r as the reconcile.Reconciler. The MongoDB watch only
+// triggers a reconcile when the spec actually changed; the owned-resource watches (which enqueue
+// the MongoDB resource set as the child's controller owner) only
+// trigger when something about the child that the agent cares about actually changed. Without
+// this, a status-only flip on any child would otherwise hot-loop the reconciler.
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
-	// Create a new controller
-	c, err := controller.New("replicaset-controller", mgr, controller.Options{Reconciler: r})
-	if err != nil {
-		return err
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("replicaset-controller").
+		For(&mdbv1.MongoDB{}, builder.WithPredicates(specChangedPredicate())).
+		Owns(&appsv1.StatefulSet{}, builder.WithPredicates(statefulSetChangedPredicate())).
+		Owns(&corev1.ConfigMap{}, builder.WithPredicates(childChangedPredicate())).
+		Owns(&corev1.Service{}, builder.WithPredicates(childChangedPredicate())).
+		Complete(r)
+}
+
+// specChangedPredicate ignores MongoDB updates that didn't change Generation (status-only
+// writes), while always letting Create and Delete events through.
+func specChangedPredicate() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return e.ObjectOld.GetGeneration() != e.ObjectNew.GetGeneration()
+		},
 	}
+}
 
-	// Watch for changes to primary resource MongoDB
-	err = c.Watch(&source.Kind{Type: &mdbv1.MongoDB{}}, &handler.EnqueueRequestForObject{})
-	if err != nil {
-		return err
+// childChangedPredicate fires only when a child's ResourceVersion actually changed, filtering
+// out the resync events controller-runtime periodically replays.
+func childChangedPredicate() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return e.ObjectOld.GetResourceVersion() != e.ObjectNew.GetResourceVersion()
+		},
+	}
+}
+
+// statefulSetChangedPredicate additionally lets through StatefulSet updates where
+// status.readyReplicas changed, since that's what Reconcile polls to decide readiness.
+func statefulSetChangedPredicate() predicate.Predicate {
+	childPredicate := childChangedPredicate()
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldSts, oldOK := e.ObjectOld.(*appsv1.StatefulSet)
+			newSts, newOK := e.ObjectNew.(*appsv1.StatefulSet)
+			if oldOK && newOK && oldSts.Status.ReadyReplicas != newSts.Status.ReadyReplicas {
+				return true
+			}
+			return childPredicate.Update(e)
+		},
 	}
-	return nil
 }
 
 // blank assignment to verify that ReplicaSetReconciler implements reconcile.Reconciler
@@ -66,8 +123,10 @@ var _ reconcile.Reconciler = &ReplicaSetReconciler{}
 type ReplicaSetReconciler struct {
 	// This client, initialized using mgr.Client() above, is a split client
 	// that reads objects from the cache and writes to the apiserver
-	client mdbClient.Client
-	scheme *runtime.Scheme
+	client        mdbClient.Client
+	scheme        *runtime.Scheme
+	statusUpdater *mdbv1.Updater
+	cfg           config.OperatorConfig
 }
 
 // Reconcile reads that state of the cluster for a MongoDB object and makes changes based on the state read
@@ -95,39 +154,158 @@ func (r *ReplicaSetReconciler) Reconcile(request reconcile.Request) (reconcile.R
 		return reconcile.Result{}, err
 	}
 
-	// TODO: Read current automation config version from config map
-
-	if err := r.ensureAutomationConfig(mdb); err != nil {
+	acVersion, err := r.ensureAutomationConfig(mdb)
+	if err != nil {
 		log.Warnf("failed creating config map: %s", err)
-		return reconcile.Result{}, err
+		return r.failedStatus(&mdb, err)
 	}
 
-	// TODO: Create the service for the MDB resource
+	if err := r.ensureService(mdb); err != nil {
+		log.Warnf("error ensuring headless Service: %s", err)
+		return r.failedStatus(&mdb, err)
+	}
 
-	sts, err := buildStatefulSet(mdb)
+	sts, err := r.buildStatefulSet(mdb)
 	if err != nil {
 		log.Warnf("error building StatefulSet: %s", err)
-		return reconcile.Result{}, nil
+		return r.failedStatus(&mdb, err)
 	}
 
 	if err = r.client.CreateOrUpdate(&sts); err != nil {
 		log.Warnf("error creating/updating StatefulSet: %s", err)
+		return r.failedStatus(&mdb, err)
+	}
+
+	ready := sts.Status.ReadyReplicas == int32(mdb.Spec.Members)
+	stsCondition := metav1.ConditionFalse
+	rollingUpdateCondition := metav1.ConditionTrue
+	if ready {
+		stsCondition = metav1.ConditionTrue
+		rollingUpdateCondition = metav1.ConditionFalse
+	}
+
+	phase := mdbv1.PhaseRunning
+	if !ready {
+		phase = mdbv1.PhasePending
+	}
+	// Conditions and CurrentAutomationConfigVersion are set here, inside modify, rather than on
+	// mdb.Status beforehand: Updater.Update takes its MergeFrom "before" snapshot from mdb as it
+	// stands when this closure runs, so mutating mdb.Status any earlier makes the before/after
+	// states identical for that field and drops it from the outgoing patch.
+	if err := r.statusUpdater.Update(context.TODO(), &mdb, func(status *mdbv1.MongoDBStatus) {
+		status.Phase = phase
+		status.CurrentStatefulSetReplicas = int(sts.Status.ReadyReplicas)
+		status.CurrentMongoDBMembers = mdb.Spec.Members
+		status.CurrentAutomationConfigVersion = acVersion
+		status.ObservedGeneration = mdb.Generation
+		r.setCondition(status, mdb.Generation, mdbv1.ConditionTypeAutomationConfigApplied, metav1.ConditionTrue, "ConfigMapUpdated")
+		r.setCondition(status, mdb.Generation, mdbv1.ConditionTypeStatefulSetReady, stsCondition, "StatefulSetUpdated")
+		r.setCondition(status, mdb.Generation, mdbv1.ConditionTypeRollingUpdateInProgress, rollingUpdateCondition, "StatefulSetUpdated")
+	}); err != nil {
+		log.Warnf("error updating MongoDB status: %s", err)
 		return reconcile.Result{}, err
 	}
 
+	if !ready {
+		log.Infof("Waiting for StatefulSet to finish rolling out: %d/%d ready", sts.Status.ReadyReplicas, mdb.Spec.Members)
+		return reconcile.Result{RequeueAfter: statusRequeueAfter}, nil
+	}
+
 	log.Info("Successfully finished reconciliation", "MongoDB.Spec:", mdb.Spec, "MongoDB.Status", mdb.Status)
 	return reconcile.Result{}, nil
 }
 
-func (r ReplicaSetReconciler) ensureAutomationConfig(mdb mdbv1.MongoDB) error {
-	cm, err := buildAutomationConfigConfigMap(mdb)
-	if err != nil {
+// failedStatus records a Failed phase on mdb's status before returning err to the controller so that
+// users can see reconciliation is broken without having to read operator logs.
+func (r *ReplicaSetReconciler) failedStatus(mdb *mdbv1.MongoDB, err error) (reconcile.Result, error) {
+	if statusErr := r.statusUpdater.Update(context.TODO(), mdb, func(status *mdbv1.MongoDBStatus) {
+		status.Phase = mdbv1.PhaseFailed
+		status.ObservedGeneration = mdb.Generation
+	}); statusErr != nil {
+		zap.S().Warnf("error updating MongoDB status after failure: %s", statusErr)
+	}
+	return reconcile.Result{}, err
+}
+
+func (r *ReplicaSetReconciler) setCondition(status *mdbv1.MongoDBStatus, observedGeneration int64, conditionType string, conditionStatus metav1.ConditionStatus, reason string) {
+	status.SetCondition(metav1.Condition{
+		Type:               conditionType,
+		Status:             conditionStatus,
+		Reason:             reason,
+		ObservedGeneration: observedGeneration,
+	})
+}
+
+// ensureService creates or updates the headless Service that gives each StatefulSet pod a stable,
+// ordinal-based DNS name. getDomain assumes this Service exists, so it must be reconciled before
+// the StatefulSet is built.
+func (r ReplicaSetReconciler) ensureService(mdb mdbv1.MongoDB) error {
+	svc := buildService(mdb)
+	if err := controllerutil.SetControllerReference(&mdb, &svc, r.scheme); err != nil {
 		return err
 	}
+	return r.client.CreateOrUpdate(&svc)
+}
+
+func buildService(mdb mdbv1.MongoDB) corev1.Service {
+	labels := podLabels(mdb)
+	return service.NewBuilder().
+		SetName(mdb.ServiceName()).
+		SetNamespace(mdb.Namespace).
+		SetSelector(labels).
+		SetLabels(labels).
+		SetClusterIP("None").
+		SetPublishNotReadyAddresses(true).
+		SetPorts([]corev1.ServicePort{{Port: 27017}}).
+		Build()
+}
+
+// ensureAutomationConfig builds the desired AutomationConfig from mdb.Spec, compares it against
+// whatever is currently stored in the ConfigMap, and only bumps Version when something the agent
+// cares about actually changed. It returns the version that was written so callers can record it
+// onto MongoDB.Status.CurrentAutomationConfigVersion.
+func (r ReplicaSetReconciler) ensureAutomationConfig(mdb mdbv1.MongoDB) (int, error) {
+	desiredAC := buildAutomationConfig(mdb)
+
+	existingAC, err := r.getExistingAutomationConfig(mdb)
+	if err != nil && !errors.IsNotFound(err) {
+		return 0, err
+	}
+	if err == nil {
+		if changed, fields := automationconfig.Diff(existingAC, desiredAC); changed {
+			zap.S().Infof("AutomationConfig changed, bumping version: %v", fields)
+			desiredAC.Version = existingAC.Version + 1
+		} else {
+			desiredAC.Version = existingAC.Version
+		}
+	}
+
+	cm, err := buildAutomationConfigConfigMap(mdb, desiredAC)
+	if err != nil {
+		return 0, err
+	}
+	if err := controllerutil.SetControllerReference(&mdb, &cm, r.scheme); err != nil {
+		return 0, err
+	}
 	if err := r.client.CreateOrUpdate(&cm); err != nil {
-		return err
+		return 0, err
+	}
+	return desiredAC.Version, nil
+}
+
+// getExistingAutomationConfig reads back and unmarshals the AutomationConfig currently stored in
+// mdb's ConfigMap, so ensureAutomationConfig has something to diff the desired config against.
+func (r ReplicaSetReconciler) getExistingAutomationConfig(mdb mdbv1.MongoDB) (automationconfig.AutomationConfig, error) {
+	cm := corev1.ConfigMap{}
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Name: mdb.ConfigMapName(), Namespace: mdb.Namespace}, &cm); err != nil {
+		return automationconfig.AutomationConfig{}, err
+	}
+
+	ac := automationconfig.AutomationConfig{}
+	if err := json.Unmarshal([]byte(cm.Data[automationConfigKey]), &ac); err != nil {
+		return automationconfig.AutomationConfig{}, err
 	}
-	return nil
+	return ac, nil
 }
 
 func buildAutomationConfig(mdb mdbv1.MongoDB) automationconfig.AutomationConfig {
@@ -138,12 +316,27 @@ func buildAutomationConfig(mdb mdbv1.MongoDB) automationconfig.AutomationConfig
 		SetDomain(domain).
 		SetMembers(mdb.Spec.Members).
 		SetMongoDBVersion(mdb.Spec.Version).
-		SetAutomationConfigVersion(1). // TODO: Correctly set the version
+		SetAutomationConfigVersion(1).
+		SetProcesses(buildProcesses(mdb, domain)).
 		Build()
 }
 
-func buildAutomationConfigConfigMap(mdb mdbv1.MongoDB) (corev1.ConfigMap, error) {
-	ac := buildAutomationConfig(mdb)
+// buildProcesses returns one Process per StatefulSet ordinal, with the host name
+// <name>-<ordinal>.<domain> for the given domain, so Diff can detect membership
+// changes driven by mdb.Spec.Members.
+func buildProcesses(mdb mdbv1.MongoDB, domain string) []automationconfig.Process {
+	processes := make([]automationconfig.Process, mdb.Spec.Members)
+	for i := range processes {
+		hostName := fmt.Sprintf("%s-%d.%s", mdb.Name, i, domain)
+		processes[i] = automationconfig.Process{
+			Name:     hostName,
+			HostName: hostName,
+		}
+	}
+	return processes
+}
+
+func buildAutomationConfigConfigMap(mdb mdbv1.MongoDB, ac automationconfig.AutomationConfig) (corev1.ConfigMap, error) {
 	acBytes, err := json.Marshal(ac)
 	if err != nil {
 		return corev1.ConfigMap{}, err
@@ -156,19 +349,33 @@ func buildAutomationConfigConfigMap(mdb mdbv1.MongoDB) (corev1.ConfigMap, error)
 		Build(), nil
 }
 
+const (
+	dataVolumeName         = "data-volume"
+	automationConfigVolume = "automation-config"
+	versionUpgradeHookName = "mongod-version-upgrade-hook"
+)
+
 // buildStatefulSet takes a MongoDB resource and converts it into
 // the corresponding stateful set
-func buildStatefulSet(mdb mdbv1.MongoDB) (appsv1.StatefulSet, error) {
-	labels := map[string]string{
-		"dummy": "label",
-	}
+func (r ReplicaSetReconciler) buildStatefulSet(mdb mdbv1.MongoDB) (appsv1.StatefulSet, error) {
+	labels := podLabels(mdb)
 	agentContainer := corev1.Container{
 		Name:      agentName,
-		Image:     os.Getenv(agentImageEnvVariable),
+		Image:     r.cfg.AgentImage,
 		Resources: resourcerequirements.Defaults(),
 		Command:   []string{"agent/mongodb-agent", "-cluster=/var/lib/automation/config/automation-config.json"},
 	}
 
+	// The version-upgrade-hook init container stages the mongod binary matching mdb.Spec.Version
+	// into data-volume, which the agent container mounts at the same path, before the agent starts.
+	versionUpgradeHookContainer := corev1.Container{
+		Name:  versionUpgradeHookName,
+		Image: r.cfg.VersionUpgradeHookImage,
+		Command: []string{
+			"cp", fmt.Sprintf("/mongodb-versions/%s/mongod", mdb.Spec.Version), "/data/mongod",
+		},
+	}
+
 	podSpecTemplate := corev1.PodTemplateSpec{
 		ObjectMeta: metav1.ObjectMeta{
 			Labels: labels,
@@ -180,14 +387,43 @@ func buildStatefulSet(mdb mdbv1.MongoDB) (appsv1.StatefulSet, error) {
 		},
 	}
 
-	return statefulset.NewBuilder().
+	sts, err := statefulset.NewBuilder().
 		SetPodTemplateSpec(podSpecTemplate).
 		SetNamespace(mdb.Namespace).
 		SetName(mdb.Name).
+		SetServiceName(mdb.ServiceName()).
 		SetReplicas(mdb.Spec.Members).
 		SetLabels(labels).
 		SetMatchLabels(labels).
+		AddVolume(corev1.Volume{
+			Name:         dataVolumeName,
+			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+		}).
+		AddVolume(corev1.Volume{
+			Name: automationConfigVolume,
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: mdb.ConfigMapName()},
+					Items: []corev1.KeyToPath{
+						{Key: automationConfigKey, Path: "automation-config.json"},
+					},
+				},
+			},
+		}).
+		AddInitContainer(versionUpgradeHookContainer).
+		AddVolumeMount(versionUpgradeHookName, corev1.VolumeMount{Name: dataVolumeName, MountPath: "/data"}).
+		AddVolumeMount(agentName, corev1.VolumeMount{Name: dataVolumeName, MountPath: "/data"}).
+		AddVolumeMount(agentName, corev1.VolumeMount{Name: automationConfigVolume, MountPath: "/var/lib/automation/config"}).
 		Build()
+	if err != nil {
+		return appsv1.StatefulSet{}, err
+	}
+
+	if err := controllerutil.SetControllerReference(&mdb, &sts, r.scheme); err != nil {
+		return appsv1.StatefulSet{}, err
+	}
+
+	return sts, nil
 }
 
 func getDomain(service, namespace, clusterName string) string {
@@ -195,4 +431,4 @@ func getDomain(service, namespace, clusterName string) string {
 		clusterName = "cluster.local"
 	}
 	return fmt.Sprintf("%s.%s.svc.%s", service, namespace, clusterName)
-}
\ No newline at end of file
+}
@@ -0,0 +1,90 @@
+package mongodb
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestSpecChangedPredicate(t *testing.T) {
+	pred := specChangedPredicate()
+
+	tests := []struct {
+		name                         string
+		oldGeneration, newGeneration int64
+		want                         bool
+	}{
+		{"generation unchanged is ignored", 1, 1, false},
+		{"generation bumped triggers a reconcile", 1, 2, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			oldObj := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Generation: tc.oldGeneration}}
+			newObj := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Generation: tc.newGeneration}}
+
+			if got := pred.Update(event.UpdateEvent{ObjectOld: oldObj, ObjectNew: newObj}); got != tc.want {
+				t.Errorf("Update() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestChildChangedPredicate(t *testing.T) {
+	pred := childChangedPredicate()
+
+	tests := []struct {
+		name         string
+		oldRV, newRV string
+		want         bool
+	}{
+		{"resource version unchanged is ignored", "1", "1", false},
+		{"resource version changed triggers a reconcile", "1", "2", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			oldObj := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{ResourceVersion: tc.oldRV}}
+			newObj := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{ResourceVersion: tc.newRV}}
+
+			if got := pred.Update(event.UpdateEvent{ObjectOld: oldObj, ObjectNew: newObj}); got != tc.want {
+				t.Errorf("Update() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestStatefulSetChangedPredicate(t *testing.T) {
+	pred := statefulSetChangedPredicate()
+
+	tests := []struct {
+		name               string
+		oldReady, newReady int32
+		oldRV, newRV       string
+		want               bool
+	}{
+		{"nothing changed is ignored", 1, 1, "1", "1", false},
+		{"ready replicas changed triggers a reconcile even if resource version didn't", 1, 2, "1", "1", true},
+		{"resource version changed triggers a reconcile even if ready replicas didn't", 1, 1, "1", "2", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			oldObj := &appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{ResourceVersion: tc.oldRV},
+				Status:     appsv1.StatefulSetStatus{ReadyReplicas: tc.oldReady},
+			}
+			newObj := &appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{ResourceVersion: tc.newRV},
+				Status:     appsv1.StatefulSetStatus{ReadyReplicas: tc.newReady},
+			}
+
+			if got := pred.Update(event.UpdateEvent{ObjectOld: oldObj, ObjectNew: newObj}); got != tc.want {
+				t.Errorf("Update() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Builder builds a corev1.Service, following the same fluent pattern as
+// pkg/kube/statefulset.Builder.
+type Builder struct {
+	name, namespace          string
+	labels, selector         map[string]string
+	clusterIP                string
+	publishNotReadyAddresses bool
+	ports                    []corev1.ServicePort
+}
+
+// NewBuilder returns an empty Builder.
+func NewBuilder() *Builder {
+	return &Builder{}
+}
+
+func (b *Builder) SetName(name string) *Builder {
+	b.name = name
+	return b
+}
+
+func (b *Builder) SetNamespace(namespace string) *Builder {
+	b.namespace = namespace
+	return b
+}
+
+func (b *Builder) SetLabels(labels map[string]string) *Builder {
+	b.labels = labels
+	return b
+}
+
+func (b *Builder) SetSelector(selector map[string]string) *Builder {
+	b.selector = selector
+	return b
+}
+
+// SetClusterIP sets the Service's ClusterIP. Pass "None" for a headless Service.
+func (b *Builder) SetClusterIP(clusterIP string) *Builder {
+	b.clusterIP = clusterIP
+	return b
+}
+
+// SetPublishNotReadyAddresses controls whether DNS records are published for
+// pods before they pass their readiness probe, which the automation agent
+// needs in order to reach sibling members while a replica set is forming.
+func (b *Builder) SetPublishNotReadyAddresses(publish bool) *Builder {
+	b.publishNotReadyAddresses = publish
+	return b
+}
+
+func (b *Builder) SetPorts(ports []corev1.ServicePort) *Builder {
+	b.ports = ports
+	return b
+}
+
+// Build returns the corev1.Service assembled from the Builder.
+func (b *Builder) Build() corev1.Service {
+	return corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      b.name,
+			Namespace: b.namespace,
+			Labels:    b.labels,
+		},
+		Spec: corev1.ServiceSpec{
+			ClusterIP:                b.clusterIP,
+			PublishNotReadyAddresses: b.publishNotReadyAddresses,
+			Selector:                 b.selector,
+			Ports:                    b.ports,
+		},
+	}
+}
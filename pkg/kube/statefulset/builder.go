@@ -0,0 +1,115 @@
+package statefulset
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Builder builds an appsv1.StatefulSet through a fluent API, so callers can compose a pod spec
+// from independent pieces (volumes, init containers, volume mounts) without reaching into the
+// underlying Kubernetes types directly.
+type Builder struct {
+	name, namespace, serviceName string
+	labels, matchLabels          map[string]string
+	replicas                     int
+	podSpecTemplate              corev1.PodTemplateSpec
+	volumes                      []corev1.Volume
+	initContainers               []corev1.Container
+}
+
+// NewBuilder returns an empty Builder.
+func NewBuilder() *Builder {
+	return &Builder{}
+}
+
+func (b *Builder) SetName(name string) *Builder {
+	b.name = name
+	return b
+}
+
+func (b *Builder) SetNamespace(namespace string) *Builder {
+	b.namespace = namespace
+	return b
+}
+
+func (b *Builder) SetServiceName(serviceName string) *Builder {
+	b.serviceName = serviceName
+	return b
+}
+
+func (b *Builder) SetReplicas(replicas int) *Builder {
+	b.replicas = replicas
+	return b
+}
+
+func (b *Builder) SetLabels(labels map[string]string) *Builder {
+	b.labels = labels
+	return b
+}
+
+func (b *Builder) SetMatchLabels(matchLabels map[string]string) *Builder {
+	b.matchLabels = matchLabels
+	return b
+}
+
+func (b *Builder) SetPodTemplateSpec(podSpecTemplate corev1.PodTemplateSpec) *Builder {
+	b.podSpecTemplate = podSpecTemplate
+	return b
+}
+
+// AddVolume adds a volume to the pod template, to be mounted by one or more containers via
+// AddVolumeMount.
+func (b *Builder) AddVolume(volume corev1.Volume) *Builder {
+	b.volumes = append(b.volumes, volume)
+	return b
+}
+
+// AddInitContainer appends an init container to the pod template.
+func (b *Builder) AddInitContainer(container corev1.Container) *Builder {
+	b.initContainers = append(b.initContainers, container)
+	return b
+}
+
+// AddVolumeMount mounts an existing volume into the named container. The container must already
+// have been added via SetPodTemplateSpec or AddInitContainer.
+func (b *Builder) AddVolumeMount(containerName string, mount corev1.VolumeMount) *Builder {
+	for i := range b.podSpecTemplate.Spec.Containers {
+		if b.podSpecTemplate.Spec.Containers[i].Name == containerName {
+			b.podSpecTemplate.Spec.Containers[i].VolumeMounts = append(b.podSpecTemplate.Spec.Containers[i].VolumeMounts, mount)
+			return b
+		}
+	}
+	for i := range b.initContainers {
+		if b.initContainers[i].Name == containerName {
+			b.initContainers[i].VolumeMounts = append(b.initContainers[i].VolumeMounts, mount)
+			return b
+		}
+	}
+	return b
+}
+
+// Build returns the appsv1.StatefulSet assembled from the Builder.
+func (b *Builder) Build() (appsv1.StatefulSet, error) {
+	replicas := int32(b.replicas)
+
+	podSpec := b.podSpecTemplate
+	podSpec.Spec.Volumes = append(podSpec.Spec.Volumes, b.volumes...)
+	podSpec.Spec.InitContainers = append(podSpec.Spec.InitContainers, b.initContainers...)
+
+	return appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      b.name,
+			Namespace: b.namespace,
+			Labels:    b.labels,
+		},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas:    &replicas,
+			ServiceName: b.serviceName,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: b.matchLabels,
+			},
+			Template: podSpec,
+		},
+	}, nil
+}